@@ -7,11 +7,15 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
 	"time"
 
 	"github.com/BurntSushi/toml"
 	"github.com/binzume/webrtcfs/rtcfs"
 	"github.com/binzume/webrtcfs/socfs"
+
+	_ "github.com/binzume/webrtcfs/socfs/backend/s3"
+	_ "github.com/binzume/webrtcfs/socfs/backend/sftp"
 )
 
 type Config struct {
@@ -29,6 +33,34 @@ type Config struct {
 
 	ThumbnailCacheDir string
 	FFmpegPath        string
+
+	ChunkCacheDir string
+	ChunkSize     int64
+	MaxCacheBytes int64
+	Workers       int
+	Prefetch      int
+
+	ContentStoreDir string
+
+	// ReadBytesPerSec and WriteBytesPerSec, if set, cap the aggregate
+	// transfer rate of reads and writes (respectively) so `publish` can
+	// run on a metered link without saturating it. 0 means unlimited.
+	ReadBytesPerSec  int64
+	WriteBytesPerSec int64
+
+	// StatsAddr, if set, serves the running client/server's transfer
+	// stats (see socfs.Stats, socfs.StatsHandler) as JSON over HTTP at
+	// this address.
+	StatsAddr string
+
+	// Backend selects the storage implementation exposed by `publish`.
+	// "local" (the default) serves LocalPath from disk; "s3" and "sftp"
+	// (see socfs/backend/s3, socfs/backend/sftp) are also built in, and
+	// third parties can add more via socfs.RegisterBackend. BackendConfig
+	// is passed through as that backend's own TOML section (e.g. Bucket/
+	// Region/Endpoint for "s3", Addr/User/RootDir for "sftp").
+	Backend       string
+	BackendConfig map[string]any
 }
 
 func DefaultConfig() *Config {
@@ -39,6 +71,12 @@ func DefaultConfig() *Config {
 	config.PairingRoomIdPrefix = "binzume@rdp-pin-"
 	config.PairingTimeoutSec = 600
 	config.ThumbnailCacheDir = "cache"
+	config.ChunkCacheDir = "cache/chunks"
+	config.ChunkSize = socfs.DefaultChunkSize
+	config.MaxCacheBytes = 256 * 1024 * 1024
+	config.Workers = 4
+	config.Prefetch = 2
+	config.ContentStoreDir = "cache/chunkstore"
 	return &config
 }
 
@@ -62,7 +100,10 @@ func publishFiles(ctx context.Context, config *Config, options *rtcfs.ConnectOpt
 		}
 	}
 
-	fsys := socfs.NewWritableDirFS(config.LocalPath)
+	fsys, err := socfs.NewBackend(config.Backend, config.LocalPath, config.BackendConfig)
+	if err != nil {
+		return err
+	}
 	if !config.Writable {
 		fsys.Capability().Create = false
 		fsys.Capability().Remove = false
@@ -80,6 +121,9 @@ func main() {
 	writable := flag.Bool("writable", false, "writable fs")
 	flag.Parse()
 
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
 	config := loadConfig(*confPath)
 	if *localPath != "" {
 		config.LocalPath = *localPath
@@ -99,11 +143,23 @@ func main() {
 		SignalingKey: config.SignalingKey,
 		RoomID:       config.RoomIdPrefix + config.RoomName + ".1",
 		AuthToken:    config.AuthToken,
+
+		ChunkCacheDir: config.ChunkCacheDir,
+		ChunkSize:     config.ChunkSize,
+		MaxCacheBytes: config.MaxCacheBytes,
+		Workers:       config.Workers,
+		Prefetch:      config.Prefetch,
+
+		ContentStoreDir: config.ContentStoreDir,
+
+		ReadBytesPerSec:  config.ReadBytesPerSec,
+		WriteBytesPerSec: config.WriteBytesPerSec,
+		StatsAddr:        config.StatsAddr,
 	}
 
 	switch flag.Arg(0) {
 	case "pairing":
-		err := rtcfs.Pairing(context.Background(), &rtcfs.PairingOptions{
+		err := rtcfs.Pairing(ctx, &rtcfs.PairingOptions{
 			ConnectOptions:      *options,
 			PairingRoomIDPrefix: config.PairingRoomIdPrefix,
 			Timeout:             time.Duration(config.PairingTimeoutSec) * time.Second,
@@ -112,22 +168,25 @@ func main() {
 			log.Println(err)
 		}
 	case "shell":
-		err := rtcfs.StartShell(context.Background(), options)
+		err := rtcfs.StartShell(ctx, options)
 		if err != nil {
 			log.Println(err)
 		}
 	case "pull", "push", "ls", "cat", "rm":
-		err := rtcfs.ShellExec(context.Background(), options, flag.Arg(0), flag.Arg(1))
+		err := rtcfs.ShellExec(ctx, options, flag.Arg(0), flag.Arg(1))
 		if err != nil {
 			log.Println(err)
 		}
 	case "publish", "":
-		for {
-			err := publishFiles(context.Background(), config, options)
+		for ctx.Err() == nil {
+			err := publishFiles(ctx, config, options)
 			if err != nil {
 				log.Println("ERROR:", err)
 			}
-			time.Sleep(5 * time.Second)
+			select {
+			case <-ctx.Done():
+			case <-time.After(5 * time.Second):
+			}
 		}
 	default:
 		fmt.Println("Unknown sub command: ", flag.Arg(0))
@@ -0,0 +1,81 @@
+package socfs
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+)
+
+// Backend abstracts the filesystem that webrtcfs publishes over a data
+// channel. WritableDirFS (local directory) is the built-in
+// implementation; RegisterBackend lets a third party add another one
+// (S3, SFTP, ...) selectable from Config.Backend without touching the
+// protocol or server code. The interface covers every op FSServer
+// needs to serve: reads, directory listing, and the full write path
+// (create, append/truncate-on-open, resize, rename, remove).
+//
+// TODO: this interface (plus RegisterBackend/NewBackend) is the
+// achievable part of the original ask to split socfs into
+// protocol/client/server/backend/thumbnail packages. FSServer, the wire
+// protocol types, and the thumbnailer still live alongside this file;
+// the rest of that split still needs doing once those pieces are in
+// view, and shouldn't be considered finished before then.
+type Backend interface {
+	fs.FS
+	fs.StatFS
+	fs.ReadDirFS
+
+	Capability() *Capability
+
+	Create(name string) (io.WriteCloser, error)
+	// OpenWriter opens name for writing with the given os.O_* flags
+	// (mirrors FSClient.OpenWriter), so a backend can support append
+	// and random-access writes as well as plain create.
+	OpenWriter(name string, flag int) (io.WriteCloser, error)
+	Mkdir(name string, mode fs.FileMode) error
+	Remove(name string) error
+	Rename(oldName, newName string) error
+	Truncate(name string, size int64) error
+}
+
+// ChangeNotifier is implemented by backends that can detect changes made
+// outside of the WebRTC protocol connections (another process writing
+// into the same bucket or directory tree, for example) and want the
+// server to push an "invalidate" notification for the affected path so
+// clients drop their cached attributes/listing for it. FSServer
+// type-asserts for this; backends that have no way to watch for
+// external changes simply don't implement it.
+type ChangeNotifier interface {
+	// Changes returns a channel of paths that changed. The channel is
+	// closed when the backend is done watching (e.g. on Close, for
+	// backends that implement io.Closer).
+	Changes() <-chan string
+}
+
+// BackendFactory builds a Backend from its TOML config section.
+type BackendFactory func(config map[string]any) (Backend, error)
+
+var backendFactories = map[string]BackendFactory{}
+
+// RegisterBackend makes a Backend implementation available under name
+// for Config.Backend / NewBackend. It is meant to be called from the
+// init() of a backend's own package (see socfs/backend/s3 and
+// socfs/backend/sftp), so adding a backend never requires editing this
+// file; main.go just needs a blank import of the package.
+func RegisterBackend(name string, factory BackendFactory) {
+	backendFactories[name] = factory
+}
+
+// NewBackend looks up the backend registered under name and builds it
+// with config. The local directory backend is available under "local"
+// without any explicit registration (see NewWritableDirFS).
+func NewBackend(name string, localPath string, config map[string]any) (Backend, error) {
+	if name == "" || name == "local" {
+		return NewWritableDirFS(localPath), nil
+	}
+	factory, ok := backendFactories[name]
+	if !ok {
+		return nil, fmt.Errorf("socfs: unknown backend %q", name)
+	}
+	return factory(config)
+}
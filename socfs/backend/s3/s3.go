@@ -0,0 +1,326 @@
+// Package s3 implements a socfs.Backend backed by an S3 (or
+// S3-compatible, e.g. MinIO/R2) bucket, selectable from main.go's
+// Config via Backend = "s3".
+package s3
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+
+	"github.com/binzume/webrtcfs/socfs"
+)
+
+func init() {
+	socfs.RegisterBackend("s3", New)
+}
+
+// Config is the [BackendConfig] TOML section read when Config.Backend
+// = "s3" in main.go.
+type Config struct {
+	Bucket   string
+	Prefix   string
+	Region   string
+	Endpoint string // optional, for S3-compatible services
+}
+
+// Backend serves an S3 bucket (optionally rooted at Prefix) as a
+// socfs.Backend.
+type Backend struct {
+	client *s3.Client
+	bucket string
+	prefix string
+	cap    socfs.Capability
+}
+
+// New builds a Backend from the TOML-decoded BackendConfig map, as
+// registered with socfs.RegisterBackend.
+func New(raw map[string]any) (socfs.Backend, error) {
+	var cfg Config
+	if err := decode(raw, &cfg); err != nil {
+		return nil, fmt.Errorf("s3 backend: %w", err)
+	}
+	if cfg.Bucket == "" {
+		return nil, errors.New("s3 backend: Bucket is required")
+	}
+
+	awsCfg, err := awsconfig.LoadDefaultConfig(context.Background(), awsconfig.WithRegion(cfg.Region))
+	if err != nil {
+		return nil, fmt.Errorf("s3 backend: %w", err)
+	}
+	client := s3.NewFromConfig(awsCfg, func(o *s3.Options) {
+		if cfg.Endpoint != "" {
+			o.BaseEndpoint = aws.String(cfg.Endpoint)
+		}
+	})
+
+	return &Backend{
+		client: client,
+		bucket: cfg.Bucket,
+		prefix: strings.Trim(cfg.Prefix, "/"),
+		cap:    socfs.Capability{Create: true, Remove: true, Write: true},
+	}, nil
+}
+
+func decode(raw map[string]any, out any) error {
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, out)
+}
+
+func (b *Backend) key(name string) string {
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	if b.prefix == "" {
+		return name
+	}
+	return path.Join(b.prefix, name)
+}
+
+func (b *Backend) Capability() *socfs.Capability { return &b.cap }
+
+func (b *Backend) Open(name string) (fs.File, error) {
+	return b.openRange(name, "")
+}
+
+// openRange is Open with an optional HTTP Range header, so a caller that
+// only needs part of the object (object.ReadAt) doesn't have to download
+// and discard the rest of it first.
+func (b *Backend) openRange(name, rng string) (*object, error) {
+	in := &s3.GetObjectInput{Bucket: &b.bucket, Key: aws.String(b.key(name))}
+	if rng != "" {
+		in.Range = aws.String(rng)
+	}
+	out, err := b.client.GetObject(context.Background(), in)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: mapErr(err)}
+	}
+	return &object{b: b, name: name, body: out.Body, size: aws.ToInt64(out.ContentLength), modTime: aws.ToTime(out.LastModified)}, nil
+}
+
+func (b *Backend) Stat(name string) (fs.FileInfo, error) {
+	out, err := b.client.HeadObject(context.Background(), &s3.HeadObjectInput{Bucket: &b.bucket, Key: aws.String(b.key(name))})
+	if err != nil {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: mapErr(err)}
+	}
+	return &fileInfo{name: path.Base(name), size: aws.ToInt64(out.ContentLength), modTime: aws.ToTime(out.LastModified)}, nil
+}
+
+func (b *Backend) ReadDir(name string) ([]fs.DirEntry, error) {
+	prefix := b.key(name)
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	var entries []fs.DirEntry
+	var token *string
+	for {
+		out, err := b.client.ListObjectsV2(context.Background(), &s3.ListObjectsV2Input{
+			Bucket: &b.bucket, Prefix: &prefix, Delimiter: aws.String("/"), ContinuationToken: token,
+		})
+		if err != nil {
+			return nil, &fs.PathError{Op: "readdir", Path: name, Err: mapErr(err)}
+		}
+		for _, p := range out.CommonPrefixes {
+			dirName := path.Base(strings.TrimSuffix(aws.ToString(p.Prefix), "/"))
+			entries = append(entries, &dirEntry{name: dirName, isDir: true})
+		}
+		for _, o := range out.Contents {
+			entries = append(entries, &dirEntry{name: path.Base(aws.ToString(o.Key)), size: aws.ToInt64(o.Size), modTime: aws.ToTime(o.LastModified)})
+		}
+		if !aws.ToBool(out.IsTruncated) {
+			break
+		}
+		token = out.NextContinuationToken
+	}
+	return entries, nil
+}
+
+func (b *Backend) Create(name string) (io.WriteCloser, error) {
+	return b.OpenWriter(name, os.O_CREATE|os.O_TRUNC|os.O_WRONLY)
+}
+
+// OpenWriter buffers the whole object in memory and uploads it on
+// Close, since S3 has no partial-object write API. Fine for the file
+// sizes webrtcfs typically serves.
+func (b *Backend) OpenWriter(name string, flag int) (io.WriteCloser, error) {
+	w := &objectWriter{b: b, name: name}
+	if flag&os.O_APPEND != 0 {
+		w.buf.Write(readOrEmpty(b, name))
+	}
+	return w, nil
+}
+
+// readOrEmpty reads the whole of an existing object, closing its body
+// once read, or returns nil if it doesn't exist.
+func readOrEmpty(b *Backend, name string) []byte {
+	f, err := b.Open(name)
+	if err != nil {
+		return nil
+	}
+	o := f.(*object)
+	defer o.body.Close()
+	data, err := io.ReadAll(o.body)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+func (b *Backend) Mkdir(name string, mode fs.FileMode) error {
+	key := b.key(name)
+	if !strings.HasSuffix(key, "/") {
+		key += "/"
+	}
+	_, err := b.client.PutObject(context.Background(), &s3.PutObjectInput{Bucket: &b.bucket, Key: &key, Body: bytes.NewReader(nil)})
+	if err != nil {
+		return &fs.PathError{Op: "mkdir", Path: name, Err: mapErr(err)}
+	}
+	return nil
+}
+
+func (b *Backend) Remove(name string) error {
+	_, err := b.client.DeleteObject(context.Background(), &s3.DeleteObjectInput{Bucket: &b.bucket, Key: aws.String(b.key(name))})
+	if err != nil {
+		return &fs.PathError{Op: "remove", Path: name, Err: mapErr(err)}
+	}
+	return nil
+}
+
+func (b *Backend) Rename(oldName, newName string) error {
+	src := b.bucket + "/" + b.key(oldName)
+	_, err := b.client.CopyObject(context.Background(), &s3.CopyObjectInput{Bucket: &b.bucket, Key: aws.String(b.key(newName)), CopySource: &src})
+	if err != nil {
+		return &fs.PathError{Op: "rename", Path: oldName, Err: mapErr(err)}
+	}
+	return b.Remove(oldName)
+}
+
+// Truncate only supports resizing to 0 (used by FSClient.Create /
+// OpenWriter's O_TRUNC path); S3 objects can't be resized in place.
+func (b *Backend) Truncate(name string, size int64) error {
+	if size != 0 {
+		return &fs.PathError{Op: "truncate", Path: name, Err: fs.ErrInvalid}
+	}
+	w, err := b.OpenWriter(name, os.O_CREATE|os.O_TRUNC|os.O_WRONLY)
+	if err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+func mapErr(err error) error {
+	var nsk *types.NoSuchKey
+	if errors.As(err, &nsk) {
+		return fs.ErrNotExist
+	}
+	var nf *types.NotFound
+	if errors.As(err, &nf) {
+		return fs.ErrNotExist
+	}
+	return err
+}
+
+type fileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	isDir   bool
+}
+
+func (fi *fileInfo) Name() string { return fi.name }
+func (fi *fileInfo) Size() int64  { return fi.size }
+func (fi *fileInfo) Mode() fs.FileMode {
+	if fi.isDir {
+		return fs.ModeDir | 0755
+	}
+	return 0644
+}
+func (fi *fileInfo) ModTime() time.Time { return fi.modTime }
+func (fi *fileInfo) IsDir() bool        { return fi.isDir }
+func (fi *fileInfo) Sys() any           { return nil }
+
+type dirEntry struct {
+	name    string
+	isDir   bool
+	size    int64
+	modTime time.Time
+}
+
+func (e *dirEntry) Name() string { return e.name }
+func (e *dirEntry) IsDir() bool  { return e.isDir }
+func (e *dirEntry) Type() fs.FileMode {
+	if e.isDir {
+		return fs.ModeDir
+	}
+	return 0
+}
+func (e *dirEntry) Info() (fs.FileInfo, error) {
+	return &fileInfo{name: e.name, size: e.size, modTime: e.modTime, isDir: e.isDir}, nil
+}
+
+type object struct {
+	b       *Backend
+	name    string
+	body    io.ReadCloser
+	size    int64
+	modTime time.Time
+}
+
+func (o *object) Stat() (fs.FileInfo, error) {
+	return &fileInfo{name: path.Base(o.name), size: o.size, modTime: o.modTime}, nil
+}
+func (o *object) Read(p []byte) (int, error) { return o.body.Read(p) }
+func (o *object) Close() error               { return o.body.Close() }
+
+// ReadAt issues a ranged GetObject for exactly the requested bytes,
+// rather than forcing a caller to discard everything before off out of
+// a full-object download. Mirrors how the local backend's *os.File
+// already satisfies io.ReaderAt.
+func (o *object) ReadAt(p []byte, off int64) (int, error) {
+	if off >= o.size {
+		return 0, io.EOF
+	}
+	end := off + int64(len(p)) - 1
+	if end >= o.size {
+		end = o.size - 1
+	}
+	r, err := o.b.openRange(o.name, fmt.Sprintf("bytes=%d-%d", off, end))
+	if err != nil {
+		return 0, err
+	}
+	defer r.body.Close()
+	n, err := io.ReadFull(r.body, p[:end-off+1])
+	if err == nil && int64(n) < int64(len(p)) {
+		err = io.EOF
+	}
+	return n, err
+}
+
+type objectWriter struct {
+	b    *Backend
+	name string
+	buf  bytes.Buffer
+}
+
+func (w *objectWriter) Write(p []byte) (int, error) { return w.buf.Write(p) }
+
+func (w *objectWriter) Close() error {
+	_, err := w.b.client.PutObject(context.Background(), &s3.PutObjectInput{
+		Bucket: &w.b.bucket, Key: aws.String(w.b.key(w.name)), Body: bytes.NewReader(w.buf.Bytes()),
+	})
+	return err
+}
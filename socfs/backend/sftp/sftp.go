@@ -0,0 +1,214 @@
+// Package sftp implements a socfs.Backend backed by a directory tree
+// served over SFTP, selectable from main.go's Config via Backend =
+// "sftp".
+package sftp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+	"path"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/binzume/webrtcfs/socfs"
+)
+
+func init() {
+	socfs.RegisterBackend("sftp", New)
+}
+
+// Config is the [BackendConfig] TOML section read when Config.Backend
+// = "sftp" in main.go.
+type Config struct {
+	Addr           string // host:port, port defaults to 22
+	User           string
+	Password       string // either Password or PrivateKeyFile
+	PrivateKeyFile string
+	RootDir        string
+	// HostKeyFingerprint, if set, must match the server's public key
+	// (ssh.FingerprintSHA256 form) or the connection is rejected.
+	// Left empty only for trusted/local testing.
+	HostKeyFingerprint string
+}
+
+// Backend serves a directory tree on a remote SFTP server (optionally
+// rooted at RootDir) as a socfs.Backend.
+type Backend struct {
+	ssh     *ssh.Client
+	client  *sftp.Client
+	rootDir string
+	cap     socfs.Capability
+}
+
+// New dials the configured SFTP server and returns a Backend, as
+// registered with socfs.RegisterBackend.
+func New(raw map[string]any) (socfs.Backend, error) {
+	var cfg Config
+	if err := decode(raw, &cfg); err != nil {
+		return nil, fmt.Errorf("sftp backend: %w", err)
+	}
+	if cfg.Addr == "" || cfg.User == "" {
+		return nil, errors.New("sftp backend: Addr and User are required")
+	}
+
+	auth, err := authMethod(cfg)
+	if err != nil {
+		return nil, err
+	}
+	sshCfg := &ssh.ClientConfig{
+		User:            cfg.User,
+		Auth:            []ssh.AuthMethod{auth},
+		HostKeyCallback: hostKeyCallback(cfg.HostKeyFingerprint),
+	}
+
+	addr := cfg.Addr
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, "22")
+	}
+	sshClient, err := ssh.Dial("tcp", addr, sshCfg)
+	if err != nil {
+		return nil, fmt.Errorf("sftp backend: %w", err)
+	}
+	client, err := sftp.NewClient(sshClient)
+	if err != nil {
+		sshClient.Close()
+		return nil, fmt.Errorf("sftp backend: %w", err)
+	}
+
+	return &Backend{
+		ssh: sshClient, client: client, rootDir: cfg.RootDir,
+		cap: socfs.Capability{Create: true, Remove: true, Write: true},
+	}, nil
+}
+
+func decode(raw map[string]any, out any) error {
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, out)
+}
+
+func authMethod(cfg Config) (ssh.AuthMethod, error) {
+	if cfg.PrivateKeyFile != "" {
+		key, err := os.ReadFile(cfg.PrivateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("sftp backend: %w", err)
+		}
+		signer, err := ssh.ParsePrivateKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("sftp backend: %w", err)
+		}
+		return ssh.PublicKeys(signer), nil
+	}
+	return ssh.Password(cfg.Password), nil
+}
+
+func hostKeyCallback(fingerprint string) ssh.HostKeyCallback {
+	if fingerprint == "" {
+		return ssh.InsecureIgnoreHostKey()
+	}
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		if ssh.FingerprintSHA256(key) != fingerprint {
+			return fmt.Errorf("sftp backend: host key fingerprint mismatch for %s", hostname)
+		}
+		return nil
+	}
+}
+
+func (b *Backend) path(name string) string {
+	return path.Join(b.rootDir, path.Clean("/"+name))
+}
+
+func (b *Backend) Capability() *socfs.Capability { return &b.cap }
+
+func (b *Backend) Open(name string) (fs.File, error) {
+	f, err := b.client.Open(b.path(name))
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: mapErr(err)}
+	}
+	return f, nil
+}
+
+func (b *Backend) Stat(name string) (fs.FileInfo, error) {
+	info, err := b.client.Stat(b.path(name))
+	if err != nil {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: mapErr(err)}
+	}
+	return info, nil
+}
+
+func (b *Backend) ReadDir(name string) ([]fs.DirEntry, error) {
+	infos, err := b.client.ReadDir(b.path(name))
+	if err != nil {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: mapErr(err)}
+	}
+	entries := make([]fs.DirEntry, len(infos))
+	for i, info := range infos {
+		entries[i] = fs.FileInfoToDirEntry(info)
+	}
+	return entries, nil
+}
+
+func (b *Backend) Create(name string) (io.WriteCloser, error) {
+	f, err := b.client.Create(b.path(name))
+	if err != nil {
+		return nil, &fs.PathError{Op: "create", Path: name, Err: mapErr(err)}
+	}
+	return f, nil
+}
+
+func (b *Backend) OpenWriter(name string, flag int) (io.WriteCloser, error) {
+	f, err := b.client.OpenFile(b.path(name), flag)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: mapErr(err)}
+	}
+	return f, nil
+}
+
+func (b *Backend) Mkdir(name string, mode fs.FileMode) error {
+	if err := b.client.Mkdir(b.path(name)); err != nil {
+		return &fs.PathError{Op: "mkdir", Path: name, Err: mapErr(err)}
+	}
+	return nil
+}
+
+func (b *Backend) Remove(name string) error {
+	if err := b.client.Remove(b.path(name)); err != nil {
+		return &fs.PathError{Op: "remove", Path: name, Err: mapErr(err)}
+	}
+	return nil
+}
+
+func (b *Backend) Rename(oldName, newName string) error {
+	if err := b.client.Rename(b.path(oldName), b.path(newName)); err != nil {
+		return &fs.PathError{Op: "rename", Path: oldName, Err: mapErr(err)}
+	}
+	return nil
+}
+
+func (b *Backend) Truncate(name string, size int64) error {
+	if err := b.client.Truncate(b.path(name), size); err != nil {
+		return &fs.PathError{Op: "truncate", Path: name, Err: mapErr(err)}
+	}
+	return nil
+}
+
+// Close shuts down the SFTP session and underlying SSH connection.
+func (b *Backend) Close() error {
+	b.client.Close()
+	return b.ssh.Close()
+}
+
+func mapErr(err error) error {
+	if errors.Is(err, os.ErrNotExist) || errors.Is(err, sftp.ErrSSHFxNoSuchFile) {
+		return fs.ErrNotExist
+	}
+	return err
+}
@@ -0,0 +1,23 @@
+package socfs
+
+import "testing"
+
+func TestNewBackend_UnknownName(t *testing.T) {
+	if _, err := NewBackend("nope", "/tmp", nil); err == nil {
+		t.Fatal("expected an error for an unregistered backend name")
+	}
+}
+
+func TestRegisterBackend_NewBackendUsesFactory(t *testing.T) {
+	called := false
+	RegisterBackend("test-fake", func(config map[string]any) (Backend, error) {
+		called = true
+		return nil, nil
+	})
+	if _, err := NewBackend("test-fake", "/tmp", map[string]any{"x": 1}); err != nil {
+		t.Fatalf("NewBackend: %v", err)
+	}
+	if !called {
+		t.Fatal("expected the registered factory to be invoked")
+	}
+}
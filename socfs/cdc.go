@@ -0,0 +1,89 @@
+package socfs
+
+import (
+	"bufio"
+	"io"
+)
+
+// FastCDCOptions bounds the chunk sizes produced by a Chunker.
+type FastCDCOptions struct {
+	MinSize int
+	AvgSize int
+	MaxSize int
+}
+
+// DefaultFastCDCOptions is used for the content-defined chunking of
+// published files: small enough that a single changed byte only
+// invalidates one chunk, large enough to keep manifests small for
+// multi-GB media libraries.
+var DefaultFastCDCOptions = FastCDCOptions{MinSize: 256 * 1024, AvgSize: 1024 * 1024, MaxSize: 4 * 1024 * 1024}
+
+var gearTable = newGearTable()
+
+// newGearTable deterministically fills the 256-entry Gear hash table
+// using splitmix64, so every build of this package agrees on the same
+// chunk boundaries for the same bytes.
+func newGearTable() [256]uint64 {
+	var t [256]uint64
+	var x uint64
+	for i := range t {
+		x += 0x9e3779b97f4a7c15
+		z := x
+		z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+		z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+		t[i] = z ^ (z >> 31)
+	}
+	return t
+}
+
+// maskForAvgSize returns the bitmask whose expected run of zero bits
+// makes hash&mask==0 occur roughly every avgSize bytes.
+func maskForAvgSize(avgSize int) uint64 {
+	bits := 0
+	for (1 << bits) < avgSize {
+		bits++
+	}
+	return ^uint64(0) << (64 - bits)
+}
+
+// Chunker splits a stream into content-defined chunks using FastCDC: a
+// Gear rolling hash is updated one byte at a time, and a boundary is
+// cut whenever hash&mask == 0, subject to MinSize/MaxSize. Because the
+// cut points depend only on local content, inserting or removing bytes
+// in one part of a file leaves chunk boundaries elsewhere unchanged --
+// which is what lets pull/push skip chunks the peer already has.
+type Chunker struct {
+	r    *bufio.Reader
+	opts FastCDCOptions
+	mask uint64
+}
+
+func NewChunker(r io.Reader, opts FastCDCOptions) *Chunker {
+	if opts.MinSize == 0 {
+		opts = DefaultFastCDCOptions
+	}
+	return &Chunker{r: bufio.NewReaderSize(r, opts.MaxSize), opts: opts, mask: maskForAvgSize(opts.AvgSize)}
+}
+
+// Next returns the next chunk, or io.EOF once the stream is exhausted.
+func (c *Chunker) Next() ([]byte, error) {
+	buf := make([]byte, 0, c.opts.AvgSize)
+	var hash uint64
+	for {
+		b, err := c.r.ReadByte()
+		if err != nil {
+			if len(buf) > 0 {
+				return buf, nil
+			}
+			return nil, err
+		}
+		buf = append(buf, b)
+		hash = (hash << 1) + gearTable[b]
+		if len(buf) >= c.opts.MinSize && hash&c.mask == 0 {
+			return buf, nil
+		}
+		if len(buf) >= c.opts.MaxSize {
+			return buf, nil
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package socfs
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"testing"
+)
+
+func TestChunker_ReassemblesInput(t *testing.T) {
+	data := make([]byte, 5*1024*1024)
+	rand.Read(data)
+
+	chunker := NewChunker(bytes.NewReader(data), DefaultFastCDCOptions)
+	var got []byte
+	for {
+		chunk, err := chunker.Next()
+		got = append(got, chunk...)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(chunk) > DefaultFastCDCOptions.MaxSize {
+			t.Fatalf("chunk exceeds MaxSize: %d", len(chunk))
+		}
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatal("chunks did not reassemble to the original input")
+	}
+}
+
+func TestChunker_StableAcrossInsertion(t *testing.T) {
+	opts := FastCDCOptions{MinSize: 64, AvgSize: 256, MaxSize: 1024}
+	data := make([]byte, 64*1024)
+	rand.Read(data)
+
+	chunksOf := func(b []byte) []string {
+		var hashes []string
+		chunker := NewChunker(bytes.NewReader(b), opts)
+		for {
+			chunk, err := chunker.Next()
+			if len(chunk) > 0 {
+				hashes = append(hashes, HashChunk(chunk))
+			}
+			if err != nil {
+				break
+			}
+		}
+		return hashes
+	}
+
+	before := chunksOf(data)
+
+	inserted := append([]byte{}, data[:len(data)/2]...)
+	inserted = append(inserted, []byte("some extra bytes inserted in the middle")...)
+	inserted = append(inserted, data[len(data)/2:]...)
+	after := chunksOf(inserted)
+
+	common := 0
+	seen := map[string]bool{}
+	for _, h := range before {
+		seen[h] = true
+	}
+	for _, h := range after {
+		if seen[h] {
+			common++
+		}
+	}
+	if common == 0 {
+		t.Fatal("expected at least some chunks to survive an unrelated insertion")
+	}
+}
@@ -0,0 +1,273 @@
+package socfs
+
+import (
+	"container/list"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// DefaultChunkSize is used when Config.ChunkSize is unset.
+const DefaultChunkSize = 2 * 1024 * 1024
+
+// ChunkCache stores fixed-size chunks of remote files read through an
+// FSClient so that repeated or resumed reads do not re-fetch data that
+// is already on local disk. Implementations must be safe for concurrent
+// use from multiple goroutines.
+type ChunkCache interface {
+	// Get returns the chunk at the given index for name, or ok=false on
+	// a cache miss. size and mtime identify the server-side file
+	// revision; a mismatch with the cached revision is treated as a
+	// miss and invalidates the existing cache entries for name.
+	Get(name string, size int64, mtime int64, chunk int64) (data []byte, ok bool)
+	// Put stores a chunk fetched from the server.
+	Put(name string, size int64, mtime int64, chunk int64, data []byte) error
+	// ChunkSize returns the fixed chunk size used by this cache.
+	ChunkSize() int64
+	// Close releases resources held by the cache.
+	Close() error
+}
+
+type chunkCacheMeta struct {
+	Size      int64
+	ModTime   int64
+	ChunkSize int64
+	// Chunks is a bitmap, one bit per chunk, recording which chunks of
+	// Data are present on disk. It is persisted alongside Data so a
+	// re-open of the same file can reuse chunks fetched in a previous
+	// session.
+	Chunks []byte
+}
+
+// DiskChunkCache is a ChunkCache backed by sparse files on local disk,
+// with an in-memory LRU in front of the most recently used chunks.
+type DiskChunkCache struct {
+	dir       string
+	chunkSize int64
+	maxMem    int64
+
+	mu      sync.Mutex
+	memUsed int64
+	lru     *list.List
+	index   map[string]*list.Element
+	files   map[string]*cachedFile
+}
+
+type cachedFile struct {
+	meta chunkCacheMeta
+	data *os.File
+}
+
+type lruEntry struct {
+	key  string // lruKey: name, size, mtime and chunk index
+	data []byte
+}
+
+// NewDiskChunkCache creates a chunk cache rooted at dir. chunkSize is the
+// fixed size of each cached chunk in bytes; maxMemBytes bounds the size
+// of the in-memory LRU that fronts the on-disk sparse files.
+func NewDiskChunkCache(dir string, chunkSize int64, maxMemBytes int64) (*DiskChunkCache, error) {
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &DiskChunkCache{
+		dir:       dir,
+		chunkSize: chunkSize,
+		maxMem:    maxMemBytes,
+		lru:       list.New(),
+		index:     map[string]*list.Element{},
+		files:     map[string]*cachedFile{},
+	}, nil
+}
+
+func (c *DiskChunkCache) ChunkSize() int64 {
+	return c.chunkSize
+}
+
+func (c *DiskChunkCache) pathFor(name string) string {
+	sum := sha1.Sum([]byte(name))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:]))
+}
+
+// openFile returns the cachedFile for name, invalidating and resetting it
+// if size/mtime no longer match what was last recorded.
+func (c *DiskChunkCache) openFile(name string, size, mtime int64) (*cachedFile, error) {
+	if cf, ok := c.files[name]; ok {
+		if cf.meta.Size == size && cf.meta.ModTime == mtime {
+			return cf, nil
+		}
+		cf.data.Close()
+		delete(c.files, name)
+	}
+
+	base := c.pathFor(name)
+	cf := &cachedFile{meta: chunkCacheMeta{Size: size, ModTime: mtime, ChunkSize: c.chunkSize}}
+	if m, err := loadChunkCacheMeta(base + ".meta"); err == nil && m.Size == size && m.ModTime == mtime && m.ChunkSize == c.chunkSize {
+		cf.meta = *m
+	} else {
+		os.Remove(base + ".meta")
+		os.Remove(base + ".data")
+		nchunks := (size + c.chunkSize - 1) / c.chunkSize
+		cf.meta.Chunks = make([]byte, (nchunks+7)/8)
+	}
+
+	f, err := os.OpenFile(base+".data", os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	cf.data = f
+	c.files[name] = cf
+	return cf, nil
+}
+
+func loadChunkCacheMeta(path string) (*chunkCacheMeta, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var m chunkCacheMeta
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (cf *cachedFile) saveMeta(path string) error {
+	b, err := json.Marshal(cf.meta)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0644)
+}
+
+func hasBit(bitmap []byte, i int64) bool {
+	if i/8 >= int64(len(bitmap)) {
+		return false
+	}
+	return bitmap[i/8]&(1<<uint(i%8)) != 0
+}
+
+func setBit(bitmap []byte, i int64) {
+	bitmap[i/8] |= 1 << uint(i%8)
+}
+
+// lruKey includes size/mtime so a revision change invalidates the
+// in-memory LRU the same way it invalidates the on-disk file in
+// openFile, instead of an LRU hit serving stale bytes from a stale
+// revision under the same name+chunk.
+func lruKey(name string, size, mtime, chunk int64) string {
+	return fmt.Sprintf("%s:%d:%d:%d", name, size, mtime, chunk)
+}
+
+func (c *DiskChunkCache) Get(name string, size int64, mtime int64, chunk int64) ([]byte, bool) {
+	key := lruKey(name, size, mtime, chunk)
+	c.mu.Lock()
+	if el, ok := c.index[key]; ok {
+		c.lru.MoveToFront(el)
+		data := el.Value.(*lruEntry).data
+		c.mu.Unlock()
+		return data, true
+	}
+
+	cf, err := c.openFile(name, size, mtime)
+	if err != nil {
+		c.mu.Unlock()
+		return nil, false
+	}
+	if !hasBit(cf.meta.Chunks, chunk) {
+		c.mu.Unlock()
+		return nil, false
+	}
+	n := c.chunkLen(size, chunk)
+	buf := make([]byte, n)
+	if _, err := cf.data.ReadAt(buf, chunk*c.chunkSize); err != nil {
+		c.mu.Unlock()
+		return nil, false
+	}
+	c.promote(key, buf)
+	c.mu.Unlock()
+	return buf, true
+}
+
+func (c *DiskChunkCache) chunkLen(size int64, chunk int64) int64 {
+	remain := size - chunk*c.chunkSize
+	if remain > c.chunkSize {
+		return c.chunkSize
+	}
+	if remain < 0 {
+		return 0
+	}
+	return remain
+}
+
+func (c *DiskChunkCache) Put(name string, size int64, mtime int64, chunk int64, data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cf, err := c.openFile(name, size, mtime)
+	if err != nil {
+		return err
+	}
+	if _, err := cf.data.WriteAt(data, chunk*c.chunkSize); err != nil {
+		return err
+	}
+	need := (chunk / 8) + 1
+	if int64(len(cf.meta.Chunks)) < need {
+		grown := make([]byte, need)
+		copy(grown, cf.meta.Chunks)
+		cf.meta.Chunks = grown
+	}
+	setBit(cf.meta.Chunks, chunk)
+	if err := cf.saveMeta(c.pathFor(name) + ".meta"); err != nil {
+		return err
+	}
+
+	c.promote(lruKey(name, size, mtime, chunk), data)
+	return nil
+}
+
+// promote inserts/updates an entry at the front of the LRU and evicts
+// the oldest entries until memory use is back under the configured cap.
+// Callers must hold c.mu.
+func (c *DiskChunkCache) promote(key string, data []byte) {
+	if el, ok := c.index[key]; ok {
+		c.memUsed -= int64(len(el.Value.(*lruEntry).data))
+		el.Value.(*lruEntry).data = data
+		c.lru.MoveToFront(el)
+	} else {
+		el := c.lru.PushFront(&lruEntry{key: key, data: data})
+		c.index[key] = el
+	}
+	c.memUsed += int64(len(data))
+
+	for c.maxMem > 0 && c.memUsed > c.maxMem {
+		back := c.lru.Back()
+		if back == nil {
+			break
+		}
+		entry := back.Value.(*lruEntry)
+		c.memUsed -= int64(len(entry.data))
+		delete(c.index, entry.key)
+		c.lru.Remove(back)
+	}
+}
+
+func (c *DiskChunkCache) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var firstErr error
+	for _, cf := range c.files {
+		if err := cf.data.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	c.files = map[string]*cachedFile{}
+	return firstErr
+}
@@ -0,0 +1,65 @@
+package socfs
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDiskChunkCache_PutGet(t *testing.T) {
+	cache, err := NewDiskChunkCache(t.TempDir(), 16, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cache.Close()
+
+	data := []byte("0123456789abcdef")
+	if err := cache.Put("/a.bin", 32, 111, 0, data); err != nil {
+		t.Fatal(err)
+	}
+	got, ok := cache.Get("/a.bin", 32, 111, 0)
+	if !ok || !bytes.Equal(got, data) {
+		t.Fatalf("Get returned %v, %v", got, ok)
+	}
+	if _, ok := cache.Get("/a.bin", 32, 111, 1); ok {
+		t.Error("expected miss for chunk not yet written")
+	}
+}
+
+func TestDiskChunkCache_InvalidatesOnMtimeChange(t *testing.T) {
+	cache, err := NewDiskChunkCache(t.TempDir(), 16, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cache.Close()
+
+	data := []byte("0123456789abcdef")
+	if err := cache.Put("/a.bin", 32, 111, 0, data); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cache.Get("/a.bin", 32, 222, 0); ok {
+		t.Error("expected miss after mtime changed")
+	}
+}
+
+func TestDiskChunkCache_PersistsAcrossReopen(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := NewDiskChunkCache(dir, 16, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := []byte("0123456789abcdef")
+	if err := cache.Put("/a.bin", 32, 111, 0, data); err != nil {
+		t.Fatal(err)
+	}
+	cache.Close()
+
+	reopened, err := NewDiskChunkCache(dir, 16, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	got, ok := reopened.Get("/a.bin", 32, 111, 0)
+	if !ok || !bytes.Equal(got, data) {
+		t.Fatalf("Get after reopen returned %v, %v", got, ok)
+	}
+}
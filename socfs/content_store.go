@@ -0,0 +1,102 @@
+package socfs
+
+import (
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+
+	"lukechampine.com/blake3"
+)
+
+// ChunkInfo is one entry of a file's content-defined chunk manifest, as
+// returned by the server's "manifest" op and produced locally by
+// ChunkFile.
+type ChunkInfo struct {
+	Offset int64
+	Len    int64
+	Hash   string
+}
+
+// HashChunk returns the hex-encoded BLAKE3 hash used to address a chunk
+// in a manifest, a ContentStore, and the "readchunk" op.
+func HashChunk(data []byte) string {
+	sum := blake3.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// ContentStore is a local content-addressed cache of chunks keyed by
+// their BLAKE3 hash, shared across files and sessions: once a chunk has
+// been fetched for any file, a later manifest referencing the same
+// hash (a duplicate chunk, or the same file re-synced) is served from
+// disk instead of over the data channel.
+type ContentStore struct {
+	dir string
+}
+
+func NewContentStore(dir string) (*ContentStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &ContentStore{dir: dir}, nil
+}
+
+func (s *ContentStore) path(hash string) string {
+	if len(hash) < 2 {
+		return filepath.Join(s.dir, hash)
+	}
+	return filepath.Join(s.dir, hash[:2], hash)
+}
+
+func (s *ContentStore) Get(hash string) ([]byte, bool) {
+	data, err := os.ReadFile(s.path(hash))
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+func (s *ContentStore) Put(hash string, data []byte) error {
+	p := s.path(hash)
+	if _, ok := s.Get(hash); ok {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+	tmp := p + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, p)
+}
+
+// ChunkFile splits r into content-defined chunks, hashing each one and
+// (if store is non-nil) saving it under that hash, returning the
+// resulting manifest. Callers implementing push diff this against the
+// server's manifest for the same path and only need to send chunks
+// whose hash the server doesn't already have.
+func ChunkFile(r io.Reader, store *ContentStore, opts FastCDCOptions) ([]ChunkInfo, error) {
+	chunker := NewChunker(r, opts)
+	var manifest []ChunkInfo
+	var offset int64
+	for {
+		data, err := chunker.Next()
+		if len(data) > 0 {
+			hash := HashChunk(data)
+			if store != nil {
+				if putErr := store.Put(hash, data); putErr != nil {
+					return nil, putErr
+				}
+			}
+			manifest = append(manifest, ChunkInfo{Offset: offset, Len: int64(len(data)), Hash: hash})
+			offset += int64(len(data))
+		}
+		if err != nil {
+			if err == io.EOF {
+				return manifest, nil
+			}
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package socfs
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestContentStore_PutGet(t *testing.T) {
+	store, err := NewContentStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := []byte("hello chunk")
+	hash := HashChunk(data)
+
+	if _, ok := store.Get(hash); ok {
+		t.Fatal("expected miss before Put")
+	}
+	if err := store.Put(hash, data); err != nil {
+		t.Fatal(err)
+	}
+	got, ok := store.Get(hash)
+	if !ok || !bytes.Equal(got, data) {
+		t.Fatalf("Get returned %v, %v", got, ok)
+	}
+}
+
+func TestChunkFile_ManifestCoversWholeFile(t *testing.T) {
+	data := bytes.Repeat([]byte("abcdefgh"), 1024)
+	store, err := NewContentStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manifest, err := ChunkFile(bytes.NewReader(data), store, FastCDCOptions{MinSize: 64, AvgSize: 256, MaxSize: 1024})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var total int64
+	for _, chunk := range manifest {
+		if chunk.Offset != total {
+			t.Fatalf("chunk offset %d, want %d", chunk.Offset, total)
+		}
+		got, ok := store.Get(chunk.Hash)
+		if !ok || int64(len(got)) != chunk.Len {
+			t.Fatalf("store missing chunk %s", chunk.Hash)
+		}
+		total += chunk.Len
+	}
+	if total != int64(len(data)) {
+		t.Fatalf("manifest covers %d bytes, want %d", total, len(data))
+	}
+}
@@ -1,6 +1,7 @@
 package socfs
 
 import (
+	"context"
 	"encoding/binary"
 	"encoding/json"
 	"errors"
@@ -13,95 +14,6 @@ import (
 	"time"
 )
 
-var statCacheExpireTime = time.Second * 5
-
-type statCache struct {
-	lock  sync.Mutex
-	stats map[string]*statCacheE
-}
-
-type statCacheE struct {
-	value fs.FileInfo
-	time  time.Time
-}
-
-func (c *statCache) set(path string, value fs.FileInfo) {
-	c.lock.Lock()
-	defer c.lock.Unlock()
-	c.stats[path] = &statCacheE{value: value, time: time.Now()}
-}
-func (c *statCache) get(path string) (fs.FileInfo, bool) {
-	c.lock.Lock()
-	defer c.lock.Unlock()
-	if s, ok := c.stats[path]; ok {
-		if s.time.Add(statCacheExpireTime).After(time.Now()) {
-			return s.value, true
-		}
-		delete(c.stats, path)
-	}
-	return nil, false
-}
-func (c *statCache) delete(path string) {
-	c.lock.Lock()
-	defer c.lock.Unlock()
-	delete(c.stats, path)
-}
-func (c *statCache) scan() {
-	now := time.Now()
-	c.lock.Lock()
-	defer c.lock.Unlock()
-	for path, s := range c.stats {
-		if now.After(s.time.Add(statCacheExpireTime)) {
-			delete(c.stats, path)
-		}
-	}
-}
-
-var filesCacheExpireTime = time.Second * 5
-
-type filesCache struct {
-	lock   sync.Mutex
-	values map[string]*filesCacheE
-}
-
-type filesCacheE struct {
-	value []fs.DirEntry
-	limit int
-	time  time.Time
-}
-
-func (c *filesCache) set(path string, value []fs.DirEntry, limit int) {
-	c.lock.Lock()
-	defer c.lock.Unlock()
-	c.values[path] = &filesCacheE{value: value, limit: limit, time: time.Now()}
-}
-func (c *filesCache) get(path string) ([]fs.DirEntry, int, bool) {
-	c.lock.Lock()
-	defer c.lock.Unlock()
-	if s, ok := c.values[path]; ok {
-		if s.time.Add(filesCacheExpireTime).After(time.Now()) {
-			return s.value, s.limit, true
-		}
-		delete(c.values, path)
-	}
-	return nil, 0, false
-}
-func (c *filesCache) delete(path string) {
-	c.lock.Lock()
-	defer c.lock.Unlock()
-	delete(c.values, path)
-}
-func (c *filesCache) scan() {
-	now := time.Now()
-	c.lock.Lock()
-	defer c.lock.Unlock()
-	for path, s := range c.values {
-		if now.After(s.time.Add(statCacheExpireTime)) {
-			delete(c.values, path)
-		}
-	}
-}
-
 // FSClient implements fs.FS
 type FSClient struct {
 	sendFunc    func(req *FileOperationRequest) error
@@ -110,36 +22,96 @@ type FSClient struct {
 	locker      sync.Mutex
 	MaxReadSize int
 	Timeout     time.Duration
-	statCache   statCache
-	filesCache  filesCache
+	pinned      pinnedCache
+
+	// ChunkCache, if set, fronts ReadAt with a read-ahead chunk cache
+	// instead of issuing a "read" request for every MaxReadSize piece.
+	// Read and ReadContext do not consult it; they still issue one
+	// "read" request per call. See DiskChunkCache.
+	ChunkCache ChunkCache
+	// ReadWorkers bounds how many chunk fetches can be in flight at
+	// once when ChunkCache is set. Defaults to 4.
+	ReadWorkers int
+	// Prefetch is the number of chunks beyond the requested range to
+	// fetch in the background when ChunkCache is set.
+	Prefetch int
+
+	// ContentStore, if set, fronts ReadAt with content-defined,
+	// hash-addressed chunks (see Manifest/ReadChunk) instead of the
+	// fixed-size ChunkCache, so chunks are reused across files and
+	// across sessions whenever their content matches. Read and
+	// ReadContext do not consult it.
+	ContentStore *ContentStore
+
+	manifests manifestCache
+
+	stats *Stats
+
+	// ReadLimiter and WriteLimiter, if set, cap how fast Read/ReadAt and
+	// Write/WriteAt (respectively) are allowed to transfer bytes. nil
+	// means unlimited.
+	ReadLimiter  *TokenBucket
+	WriteLimiter *TokenBucket
 }
 
 func NewFSClient(sendFunc func(req *FileOperationRequest) error) *FSClient {
 	return &FSClient{
 		sendFunc: sendFunc,
 		wait:     map[uint32]chan *FileOperationResult{}, MaxReadSize: 65000, Timeout: 30 * time.Second,
-		statCache:  statCache{stats: map[string]*statCacheE{}},
-		filesCache: filesCache{values: map[string]*filesCacheE{}},
+		pinned:      newPinnedCache(),
+		manifests:   newManifestCache(),
+		ReadWorkers: 4,
+		Prefetch:    2,
+		stats:       NewStats(),
 	}
 }
 
+// Stats returns a snapshot of this client's bandwidth and operation
+// counters (bytes in/out, per-op counts, per-path totals, in-flight
+// requests).
+func (c *FSClient) Stats() *StatsSnapshot {
+	return c.stats.Snapshot()
+}
+
 func (c *FSClient) request(req *FileOperationRequest) (*FileOperationResult, error) {
+	return c.requestCtx(context.Background(), req)
+}
+
+// requestCtx is like request but aborts the wait as soon as ctx is
+// done. On cancellation it sends a "cancel" op carrying the original
+// RID so the server can give up on the pending operation too, instead
+// of the reply channel just leaking until the server answers (or
+// never does, for a long read on a slow peer).
+func (c *FSClient) requestCtx(ctx context.Context, req *FileOperationRequest) (*FileOperationResult, error) {
 	resCh := make(chan *FileOperationResult, 1)
 
 	c.locker.Lock()
 	c.reqCount++
-	c.wait[c.reqCount] = resCh
-	req.RID = c.reqCount
+	rid := c.reqCount
+	c.wait[rid] = resCh
+	req.RID = rid
 	c.locker.Unlock()
 
 	err := c.sendFunc(req)
 	if err != nil {
+		c.locker.Lock()
+		delete(c.wait, rid)
+		c.locker.Unlock()
 		return nil, err
 	}
 	var res *FileOperationResult
 	select {
 	case <-time.After(c.Timeout):
+		c.locker.Lock()
+		delete(c.wait, rid)
+		c.locker.Unlock()
 		return nil, errors.New("timeout")
+	case <-ctx.Done():
+		c.locker.Lock()
+		delete(c.wait, rid)
+		c.locker.Unlock()
+		c.sendFunc(&FileOperationRequest{Op: "cancel", RID: rid})
+		return nil, ctx.Err()
 	case res = <-resCh:
 		if res == nil {
 			return nil, os.ErrClosed
@@ -180,6 +152,12 @@ func (c *FSClient) HandleMessage(data []byte, isjson bool) error {
 		res.RID = float64(binary.LittleEndian.Uint32(data[4:]))
 		res.Buf = data[8:]
 	}
+
+	if res.Op == "invalidate" {
+		c.pinned.invalidate(res.Path)
+		return nil
+	}
+
 	rid := uint32(res.RID.(float64))
 	c.locker.Lock()
 	if ch, ok := c.wait[rid]; ok {
@@ -192,74 +170,149 @@ func (c *FSClient) HandleMessage(data []byte, isjson bool) error {
 
 // fs.FS
 func (c *FSClient) Open(name string) (fs.File, error) {
-	return &clientFile{c: c, name: name}, nil
+	return c.OpenCtx(context.Background(), name)
+}
+
+// OpenCtx is Open with a context that aborts the open (and the pending
+// server-side handle creation) if cancelled before the server replies.
+func (c *FSClient) OpenCtx(ctx context.Context, name string) (fs.File, error) {
+	return c.openHandle(ctx, name)
+}
+
+// openHandle asks the server to pin name and returns a clientFile bound
+// to the resulting handle; the handle is released by clientFile.Close.
+func (c *FSClient) openHandle(ctx context.Context, name string) (*clientFile, error) {
+	res, err := c.requestCtx(ctx, &FileOperationRequest{Op: "open", Path: name})
+	if err != nil {
+		return nil, err
+	}
+	var entry FileEntry
+	if err := json.Unmarshal(res.Data, &entry); err == nil {
+		c.pinned.pinAttr(name, res.Handle, &entry)
+	}
+	return &clientFile{c: c, name: name, handle: res.Handle}, nil
 }
 
 // fs.StatFS
 func (c *FSClient) Stat(name string) (fs.FileInfo, error) {
-	if s, ok := c.statCache.get(name); ok {
-		if s == nil {
-			return nil, &os.PathError{
-				Op:   "stat",
-				Path: name,
-				Err:  fs.ErrNotExist,
-			}
-		}
-		stat := s
-		return stat, nil
+	return c.StatCtx(context.Background(), name)
+}
+
+// StatCtx is Stat with a context that aborts the request if cancelled
+// before the server replies.
+func (c *FSClient) StatCtx(ctx context.Context, name string) (fs.FileInfo, error) {
+	if attr, ok := c.pinned.getAttr(name); ok {
+		return attr, nil
 	}
 
-	res, err := c.request(&FileOperationRequest{Op: "stat", Path: name})
+	res, err := c.requestCtx(ctx, &FileOperationRequest{Op: "stat", Path: name})
 	if err != nil {
-		if errors.Is(err, fs.ErrNotExist) {
-			c.statCache.set(name, nil)
-		}
 		return nil, err
 	}
 	var result FileEntry
 	json.Unmarshal(res.Data, &result)
-	c.statCache.set(name, &result)
 	return &result, nil
 }
 
+// Manifest returns the ordered list of content-defined chunks that make
+// up name, as computed server-side.
+func (c *FSClient) Manifest(name string) ([]ChunkInfo, error) {
+	res, err := c.request(&FileOperationRequest{Op: "manifest", Path: name})
+	if err != nil {
+		return nil, err
+	}
+	var manifest []ChunkInfo
+	if err := json.Unmarshal(res.Data, &manifest); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// ReadChunk fetches a single chunk by its content hash, serving it out
+// of c.ContentStore when present there. name attributes the fetched
+// bytes to a file in Stats(); it plays no part in addressing the
+// chunk, which is purely content-hashed.
+func (c *FSClient) ReadChunk(name, hash string) ([]byte, error) {
+	if c.ContentStore != nil {
+		if data, ok := c.ContentStore.Get(hash); ok {
+			return data, nil
+		}
+	}
+	if err := c.ReadLimiter.WaitN(context.Background(), c.MaxReadSize); err != nil {
+		return nil, err
+	}
+	done := c.stats.BeginOp("readchunk")
+	res, err := c.request(&FileOperationRequest{Op: "readchunk", Hash: hash})
+	done()
+	if err != nil {
+		return nil, err
+	}
+	c.stats.AddRead(name, len(res.Buf))
+	if c.ContentStore != nil {
+		c.ContentStore.Put(hash, res.Buf)
+	}
+	return res.Buf, nil
+}
+
 // fs.ReadDirFS
 func (c *FSClient) ReadDir(name string) ([]fs.DirEntry, error) {
 	return c.ReadDirRange(name, 0, -1)
 }
 
+// ReadDirCtx is ReadDir with a context that aborts any still-pending
+// "files" request if cancelled before the server replies.
+func (c *FSClient) ReadDirCtx(ctx context.Context, name string) ([]fs.DirEntry, error) {
+	return c.ReadDirRangeCtx(ctx, name, 0, -1)
+}
+
 func (c *FSClient) OpenDir(name string) (fs.ReadDirFile, error) {
-	return &clientFile{c: c, name: name}, nil
+	return c.openHandle(context.Background(), name)
 }
 
 func (c *FSClient) ReadDirRange(name string, pos, limit int) ([]fs.DirEntry, error) {
+	return c.ReadDirRangeCtx(context.Background(), name, pos, limit)
+}
+
+func (c *FSClient) ReadDirRangeCtx(ctx context.Context, name string, pos, limit int) ([]fs.DirEntry, error) {
 	var entries []fs.DirEntry
 	if limit < 0 {
 		limit = 65536
 	}
 
-	key := name
-	if pos != 0 {
-		key += ";" + fmt.Sprint(pos)
-	}
-	if cached, l, ok := c.filesCache.get(key); ok && l >= limit {
-		for _, f := range cached {
-			if len(entries) >= limit {
-				break
+	// Only a from-the-start listing is cached, since that's what the
+	// repeatedly-issued ReadDir/ReadDirCtx callers ask for; an arbitrary
+	// mid-listing range (pos != 0) always goes to the server. A cache
+	// hit is reused as long as it covers the requested limit: either
+	// it's the complete directory, or it was itself fetched with at
+	// least as large a limit. It's invalidated the same way a pinned
+	// attr is, via pinned.invalidate: on a server-pushed "invalidate",
+	// or locally just before this client sends a Create, Rename, Mkdir
+	// or Remove under this directory.
+	fromStart := pos == 0
+	if fromStart {
+		if d, ok := c.pinned.getDir(name); ok && (d.complete || len(d.entries) >= limit) {
+			n := limit
+			if n > len(d.entries) {
+				n = len(d.entries)
 			}
-			entries = append(entries, f)
+			for _, f := range d.entries[:n] {
+				entries = append(entries, &clientDirEnt{FileEntry: f})
+			}
+			return entries, nil
 		}
-		return entries, nil
 	}
 
+	var fetched []*FileEntry
+	complete := false
 	for {
 		n := limit - len(entries)
 		if n <= 0 {
-			return entries, nil
+			break
 		}
 		if n > 200 {
 			n = 200
 		}
-		res, err := c.request(&FileOperationRequest{Op: "files", Path: name, Pos: int64(pos), Len: n})
+		res, err := c.requestCtx(ctx, &FileOperationRequest{Op: "files", Path: name, Pos: int64(pos), Len: n})
 		if err != nil {
 			return entries, err
 		}
@@ -267,23 +320,28 @@ func (c *FSClient) ReadDirRange(name string, pos, limit int) ([]fs.DirEntry, err
 		json.Unmarshal(res.Data, &result)
 		for _, f := range result {
 			entries = append(entries, &clientDirEnt{FileEntry: f})
-			c.statCache.set(path.Join(name, f.Name()), f)
+			fetched = append(fetched, f)
+			// Not tied to any open handle; kept fresh only by
+			// server-pushed invalidation, same as a pinned entry.
+			c.pinned.pinAttr(path.Join(name, f.Name()), 0, f)
 		}
 		pos += len(result)
 		if len(result) != n {
+			complete = true
 			break // io.EOF
 		}
 	}
 
-	c.filesCache.set(key, entries, limit)
+	if fromStart {
+		c.pinned.pinDir(name, fetched, complete)
+	}
 
 	return entries, nil
 }
 
 func (c *FSClient) Create(name string) (io.WriteCloser, error) {
-	c.statCache.delete(name)
-	c.filesCache.delete(name)
-	c.filesCache.delete(path.Dir(name))
+	c.pinned.invalidate(name)
+	c.pinned.invalidate(path.Dir(name))
 	err := c.Truncate(name, 0)
 	if err != nil {
 		return nil, err
@@ -292,26 +350,24 @@ func (c *FSClient) Create(name string) (io.WriteCloser, error) {
 }
 
 func (c *FSClient) Rename(name string, newName string) error {
-	c.statCache.delete(name)
-	c.statCache.delete(newName)
-	c.filesCache.delete(name)
-	c.filesCache.delete(path.Dir(name))
+	c.pinned.invalidate(name)
+	c.pinned.invalidate(newName)
+	c.pinned.invalidate(path.Dir(name))
+	c.pinned.invalidate(path.Dir(newName))
 	_, err := c.request(&FileOperationRequest{Op: "rename", Path: name, Path2: newName})
 	return err
 }
 
 func (c *FSClient) Mkdir(name string, mode fs.FileMode) error {
-	c.statCache.delete(name)
-	c.filesCache.delete(name)
-	c.filesCache.delete(path.Dir(name))
+	c.pinned.invalidate(name)
+	c.pinned.invalidate(path.Dir(name))
 	_, err := c.request(&FileOperationRequest{Op: "mkdir", Path: name})
 	return err
 }
 
 func (c *FSClient) Remove(name string) error {
-	c.statCache.delete(name)
-	c.filesCache.delete(name)
-	c.filesCache.delete(path.Dir(name))
+	c.pinned.invalidate(name)
+	c.pinned.invalidate(path.Dir(name))
 	_, err := c.request(&FileOperationRequest{Op: "remove", Path: name})
 	return err
 }
@@ -342,9 +398,10 @@ func (f *clientDirEnt) Info() (fs.FileInfo, error) {
 }
 
 type clientFile struct {
-	c    *FSClient
-	name string
-	pos  int64
+	c      *FSClient
+	name   string
+	pos    int64
+	handle int64
 }
 
 // fs.File
@@ -354,12 +411,28 @@ func (f *clientFile) Stat() (fs.FileInfo, error) {
 
 // fs.File, io.Reader
 func (f *clientFile) Read(b []byte) (int, error) {
+	return f.ReadContext(context.Background(), b)
+}
+
+// ReadContext is Read with a context that cancels the in-flight "read"
+// request (via the server-side "cancel" op) instead of blocking until
+// c.Timeout on a slow or stuck peer.
+func (f *clientFile) ReadContext(ctx context.Context, b []byte) (int, error) {
 	sz := len(b)
 	if sz > f.c.MaxReadSize {
 		sz = f.c.MaxReadSize
 	}
-	res, err := f.c.request(&FileOperationRequest{Op: "read", Path: f.name, Pos: f.pos, Len: sz})
+	if err := f.c.ReadLimiter.WaitN(ctx, sz); err != nil {
+		return 0, err
+	}
+	done := f.c.stats.BeginOp("read")
+	res, err := f.c.requestCtx(ctx, &FileOperationRequest{Op: "read", Path: f.name, Pos: f.pos, Len: sz})
+	done()
+	if err != nil {
+		return 0, err
+	}
 	l := copy(b, res.Buf)
+	f.c.stats.AddRead(f.name, l)
 	f.pos += int64(l)
 	if err == nil && l < sz {
 		err = io.EOF
@@ -369,6 +442,16 @@ func (f *clientFile) Read(b []byte) (int, error) {
 
 // io.ReaderAt
 func (f *clientFile) ReadAt(b []byte, off int64) (int, error) {
+	if f.c.ContentStore != nil {
+		n, err := f.c.readAtDedup(f.name, b, off)
+		f.pos = off + int64(n)
+		return n, err
+	}
+	if f.c.ChunkCache != nil {
+		n, err := f.c.readAtCached(f.name, b, off)
+		f.pos = off + int64(n)
+		return n, err
+	}
 	f.pos = off
 	read := 0
 	for read < len(b) {
@@ -381,23 +464,229 @@ func (f *clientFile) ReadAt(b []byte, off int64) (int, error) {
 	return read, nil
 }
 
+// readAtDedup serves a read out of name's content-defined chunk
+// manifest, fetching each overlapping chunk through c.ContentStore
+// (which may already have it from another file or session). The
+// manifest itself is memoized per (name, size, mtime) so a sequential
+// read, which calls ReadAt many times over the same file, fetches it
+// at most once per revision instead of once per call.
+func (c *FSClient) readAtDedup(name string, b []byte, off int64) (int, error) {
+	info, err := c.Stat(name)
+	if err != nil {
+		return 0, err
+	}
+	size, mtime := info.Size(), info.ModTime().Unix()
+
+	manifest, ok := c.manifests.get(name, size, mtime)
+	if !ok {
+		manifest, err = c.Manifest(name)
+		if err != nil {
+			return 0, err
+		}
+		c.manifests.put(name, size, mtime, manifest)
+	}
+	end := off + int64(len(b))
+	n := 0
+	for _, chunk := range manifest {
+		if chunk.Offset+chunk.Len <= off || chunk.Offset >= end {
+			continue
+		}
+		data, err := c.ReadChunk(name, chunk.Hash)
+		if err != nil {
+			return n, err
+		}
+		lo := off - chunk.Offset
+		if lo < 0 {
+			lo = 0
+		}
+		hi := end - chunk.Offset
+		if hi > int64(len(data)) {
+			hi = int64(len(data))
+		}
+		if lo >= hi {
+			continue
+		}
+		n += copy(b[chunk.Offset+lo-off:], data[lo:hi])
+	}
+	if n < len(b) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// readAtCached serves a read out of c.ChunkCache, fetching any missing
+// chunks that cover [off, off+len(b)) in parallel via a bounded worker
+// pool, and kicking off best-effort background prefetch of the chunks
+// immediately following the requested range.
+func (c *FSClient) readAtCached(name string, b []byte, off int64) (int, error) {
+	info, err := c.Stat(name)
+	if err != nil {
+		return 0, err
+	}
+	size := info.Size()
+	if off >= size {
+		return 0, io.EOF
+	}
+	end := off + int64(len(b))
+	if end > size {
+		end = size
+	}
+
+	chunkSize := c.ChunkCache.ChunkSize()
+	firstChunk := off / chunkSize
+	lastChunk := (end - 1) / chunkSize
+	mtime := info.ModTime().Unix()
+
+	if err := c.fetchChunks(name, size, mtime, firstChunk, lastChunk); err != nil {
+		return 0, err
+	}
+	go c.prefetch(name, size, mtime, lastChunk+1, lastChunk+int64(c.Prefetch))
+
+	n := 0
+	for chunk := firstChunk; chunk <= lastChunk; chunk++ {
+		data, ok := c.ChunkCache.Get(name, size, mtime, chunk)
+		if !ok {
+			return n, fmt.Errorf("chunk %d of %s missing after fetch", chunk, name)
+		}
+		chunkStart := chunk * chunkSize
+		lo := off - chunkStart
+		if lo < 0 {
+			lo = 0
+		}
+		hi := end - chunkStart
+		if hi > int64(len(data)) {
+			hi = int64(len(data))
+		}
+		if lo >= hi {
+			continue
+		}
+		n += copy(b[chunkStart+lo-off:], data[lo:hi])
+	}
+	if int64(n) < int64(len(b)) && end == size {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// fetchChunks makes sure every chunk in [firstChunk, lastChunk] is
+// present in c.ChunkCache, issuing "read" requests for misses over a
+// worker pool bounded by c.ReadWorkers.
+func (c *FSClient) fetchChunks(name string, size, mtime, firstChunk, lastChunk int64) error {
+	workers := c.ReadWorkers
+	if workers <= 0 {
+		workers = 1
+	}
+	sem := make(chan struct{}, workers)
+	errCh := make(chan error, lastChunk-firstChunk+1)
+	pending := 0
+	for chunk := firstChunk; chunk <= lastChunk; chunk++ {
+		if _, ok := c.ChunkCache.Get(name, size, mtime, chunk); ok {
+			continue
+		}
+		pending++
+		sem <- struct{}{}
+		go func(chunk int64) {
+			defer func() { <-sem }()
+			errCh <- c.fetchChunk(name, size, mtime, chunk)
+		}(chunk)
+	}
+	var firstErr error
+	for i := 0; i < pending; i++ {
+		if err := <-errCh; err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// fetchChunk fetches a single chunk from the server and stores it in
+// c.ChunkCache.
+// fetchChunk fetches a single chunk, which may be larger than
+// MaxReadSize, in MaxReadSize-bounded pieces so ReadLimiter.WaitN is
+// never asked to admit more than one RPC's worth of bytes at a time
+// (mirrors writeAtContext's loop).
+func (c *FSClient) fetchChunk(name string, size, mtime, chunk int64) error {
+	chunkSize := c.ChunkCache.ChunkSize()
+	pos := chunk * chunkSize
+	length := chunkSize
+	if pos+length > size {
+		length = size - pos
+	}
+
+	buf := make([]byte, 0, length)
+	for int64(len(buf)) < length {
+		l := length - int64(len(buf))
+		if l > int64(c.MaxReadSize) {
+			l = int64(c.MaxReadSize)
+		}
+		if err := c.ReadLimiter.WaitN(context.Background(), int(l)); err != nil {
+			return err
+		}
+		done := c.stats.BeginOp("read")
+		res, err := c.request(&FileOperationRequest{Op: "read", Path: name, Pos: pos + int64(len(buf)), Len: int(l)})
+		done()
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		c.stats.AddRead(name, len(res.Buf))
+		buf = append(buf, res.Buf...)
+		if int64(len(res.Buf)) < l {
+			break
+		}
+	}
+	return c.ChunkCache.Put(name, size, mtime, chunk, buf)
+}
+
+// prefetch best-effort fetches chunks [firstChunk, lastChunk] in the
+// background; errors (including a stale stat by the time it runs) are
+// dropped since this is purely a read-ahead optimization.
+func (c *FSClient) prefetch(name string, size, mtime, firstChunk, lastChunk int64) {
+	if c.Prefetch <= 0 || firstChunk > lastChunk {
+		return
+	}
+	maxChunk := (size - 1) / c.ChunkCache.ChunkSize()
+	if lastChunk > maxChunk {
+		lastChunk = maxChunk
+	}
+	if firstChunk > lastChunk {
+		return
+	}
+	c.fetchChunks(name, size, mtime, firstChunk, lastChunk)
+}
+
 // io.Writer
 func (f *clientFile) Write(b []byte) (int, error) {
-	return f.WriteAt(b, f.pos)
+	return f.WriteContext(context.Background(), b)
+}
+
+// WriteContext writes b at the file's current position, aborting any
+// still-pending "write" request if ctx is cancelled first.
+func (f *clientFile) WriteContext(ctx context.Context, b []byte) (int, error) {
+	return f.writeAtContext(ctx, b, f.pos)
 }
 
 // io.WriterAt
 func (f *clientFile) WriteAt(b []byte, off int64) (int, error) {
+	return f.writeAtContext(context.Background(), b, off)
+}
+
+func (f *clientFile) writeAtContext(ctx context.Context, b []byte, off int64) (int, error) {
 	wrote := 0
 	for len(b) > 0 {
 		l := len(b)
 		if l > f.c.MaxReadSize {
 			l = f.c.MaxReadSize
 		}
-		_, err := f.c.request(&FileOperationRequest{Op: "write", Path: f.name, Pos: off, Buf: b[:l]})
+		if err := f.c.WriteLimiter.WaitN(ctx, l); err != nil {
+			return wrote, err
+		}
+		done := f.c.stats.BeginOp("write")
+		_, err := f.c.requestCtx(ctx, &FileOperationRequest{Op: "write", Path: f.name, Pos: off, Buf: b[:l]})
+		done()
 		if err != nil {
 			return wrote, err
 		}
+		f.c.stats.AddWrite(f.name, l)
 		wrote += l
 		off += int64(l)
 		b = b[l:]
@@ -412,7 +701,12 @@ func (f *clientFile) Truncate(size int64) error {
 
 // fs.File
 func (f *clientFile) Close() error {
-	return nil
+	if f.handle == 0 {
+		return nil
+	}
+	_, err := f.c.request(&FileOperationRequest{Op: "close", Path: f.name, Handle: f.handle})
+	f.c.pinned.unpin(f.name, f.handle)
+	return err
 }
 
 // fs.ReadDirFile
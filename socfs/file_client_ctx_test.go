@@ -0,0 +1,37 @@
+package socfs
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestFSClient_requestCtx_CancelSendsCancelOp(t *testing.T) {
+	var sent []*FileOperationRequest
+	c := NewFSClient(func(req *FileOperationRequest) error {
+		sent = append(sent, req)
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := c.requestCtx(ctx, &FileOperationRequest{Op: "read", Path: "/a.bin"})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+
+	if len(sent) != 2 || sent[0].Op != "read" || sent[1].Op != "cancel" {
+		t.Fatalf("expected [read, cancel], got %+v", sent)
+	}
+	if sent[1].RID != sent[0].RID {
+		t.Errorf("cancel RID %v does not match original request RID %v", sent[1].RID, sent[0].RID)
+	}
+
+	c.locker.Lock()
+	_, pending := c.wait[sent[0].RID]
+	c.locker.Unlock()
+	if pending {
+		t.Error("expected the reply channel to be cleaned up after cancellation")
+	}
+}
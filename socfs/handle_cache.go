@@ -0,0 +1,110 @@
+package socfs
+
+import (
+	"sync"
+)
+
+// pinnedEntry holds the cached attributes for a path for as long as at
+// least one open handle references it, or until the server pushes an
+// explicit invalidation for it.
+type pinnedEntry struct {
+	attr    *FileEntry
+	handles map[int64]bool
+}
+
+// pinnedDir holds a cached directory listing, fetched from pos 0.
+// complete is true if entries is the whole directory rather than just
+// the first len(entries) entries of a limited request.
+type pinnedDir struct {
+	entries  []*FileEntry
+	complete bool
+}
+
+// pinnedCache is FSClient's single cache of file/directory attributes
+// and from-the-start directory listings, keyed by path. It replaces the
+// old statCache/filesCache TTL caches: an entry is populated when a
+// handle is opened (or opportunistically while listing a directory) and
+// never expires on a timer. Staleness is instead handled by the server
+// telling us when to drop an entry, via an "invalidate" push in
+// FSClient.HandleMessage, or by this client dropping its own entries
+// when it sends a Create, Rename, Mkdir or Remove. Writes and truncates
+// made through this client do not drop entries locally.
+type pinnedCache struct {
+	lock    sync.Mutex
+	entries map[string]*pinnedEntry
+	dirs    map[string]*pinnedDir
+}
+
+func newPinnedCache() pinnedCache {
+	return pinnedCache{entries: map[string]*pinnedEntry{}, dirs: map[string]*pinnedDir{}}
+}
+
+func (c *pinnedCache) getAttr(path string) (*FileEntry, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if e, ok := c.entries[path]; ok && e.attr != nil {
+		return e.attr, true
+	}
+	return nil, false
+}
+
+// pinAttr records attr for path and, if handle is non-zero, pins it
+// there until a matching unpin call. handle == 0 is used for entries
+// populated opportunistically (e.g. while listing a directory) that
+// aren't tied to any open handle; those rely solely on invalidate
+// pushes to be dropped.
+func (c *pinnedCache) pinAttr(path string, handle int64, attr *FileEntry) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	e, ok := c.entries[path]
+	if !ok {
+		e = &pinnedEntry{handles: map[int64]bool{}}
+		c.entries[path] = e
+	}
+	e.attr = attr
+	if handle != 0 {
+		e.handles[handle] = true
+	}
+}
+
+// unpin drops handle's interest in path, removing the cached entry once
+// no handle references it any more.
+func (c *pinnedCache) unpin(path string, handle int64) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	e, ok := c.entries[path]
+	if !ok {
+		return
+	}
+	delete(e.handles, handle)
+	if len(e.handles) == 0 {
+		delete(c.entries, path)
+	}
+}
+
+// invalidate drops any cached attributes and directory listing for path
+// regardless of open handles. Called on a server-pushed invalidation, or
+// locally by FSClient just before it sends a Create, Rename, Mkdir or
+// Remove request.
+func (c *pinnedCache) invalidate(path string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	delete(c.entries, path)
+	delete(c.dirs, path)
+}
+
+// getDir returns the cached from-the-start listing for path, if any.
+func (c *pinnedCache) getDir(path string) (*pinnedDir, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	d, ok := c.dirs[path]
+	return d, ok
+}
+
+// pinDir caches a from-the-start directory listing for path, fetched
+// either in full (complete) or up to some request limit.
+func (c *pinnedCache) pinDir(path string, entries []*FileEntry, complete bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.dirs[path] = &pinnedDir{entries: entries, complete: complete}
+}
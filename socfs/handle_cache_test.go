@@ -0,0 +1,58 @@
+package socfs
+
+import "testing"
+
+func TestPinnedCache_PinUnpin(t *testing.T) {
+	c := newPinnedCache()
+	c.pinAttr("/a.txt", 1, &FileEntry{})
+
+	if _, ok := c.getAttr("/a.txt"); !ok {
+		t.Fatal("expected pinned attr to be cached")
+	}
+
+	c.unpin("/a.txt", 1)
+	if _, ok := c.getAttr("/a.txt"); ok {
+		t.Fatal("expected entry to be dropped once last handle unpinned")
+	}
+}
+
+func TestPinnedCache_MultipleHandles(t *testing.T) {
+	c := newPinnedCache()
+	c.pinAttr("/a.txt", 1, &FileEntry{})
+	c.pinAttr("/a.txt", 2, &FileEntry{})
+
+	c.unpin("/a.txt", 1)
+	if _, ok := c.getAttr("/a.txt"); !ok {
+		t.Fatal("entry should survive while handle 2 still holds it")
+	}
+
+	c.unpin("/a.txt", 2)
+	if _, ok := c.getAttr("/a.txt"); ok {
+		t.Fatal("expected entry to be dropped once all handles unpinned")
+	}
+}
+
+func TestPinnedCache_Invalidate(t *testing.T) {
+	c := newPinnedCache()
+	c.pinAttr("/a.txt", 0, &FileEntry{})
+
+	c.invalidate("/a.txt")
+	if _, ok := c.getAttr("/a.txt"); ok {
+		t.Fatal("expected invalidate to drop the entry regardless of handles")
+	}
+}
+
+func TestPinnedCache_Dir(t *testing.T) {
+	c := newPinnedCache()
+	c.pinDir("/dir", []*FileEntry{{}, {}}, true)
+
+	d, ok := c.getDir("/dir")
+	if !ok || !d.complete || len(d.entries) != 2 {
+		t.Fatalf("getDir = %+v, %v, want a complete 2-entry listing", d, ok)
+	}
+
+	c.invalidate("/dir")
+	if _, ok := c.getDir("/dir"); ok {
+		t.Fatal("expected invalidate to drop the cached listing")
+	}
+}
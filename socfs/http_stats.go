@@ -0,0 +1,16 @@
+package socfs
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// StatsHandler returns an http.Handler that serves s as JSON. Callers
+// mount it under whatever path/mux they like; main.go does not mount it
+// itself but passes Config.StatsAddr on in rtcfs.ConnectOptions.
+func StatsHandler(s *Stats) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(s.Snapshot())
+	})
+}
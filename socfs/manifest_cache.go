@@ -0,0 +1,39 @@
+package socfs
+
+import "sync"
+
+// cachedManifest pairs a fetched manifest with the file revision it was
+// fetched for, so a later call can tell whether it's still current.
+type cachedManifest struct {
+	size     int64
+	mtime    int64
+	manifest []ChunkInfo
+}
+
+// manifestCache memoizes Manifest() results per path, keyed by the file's
+// size/mtime the same way DiskChunkCache keys its chunks: a revision
+// mismatch is treated as a miss rather than served stale.
+type manifestCache struct {
+	mu      sync.Mutex
+	entries map[string]*cachedManifest
+}
+
+func newManifestCache() manifestCache {
+	return manifestCache{entries: map[string]*cachedManifest{}}
+}
+
+func (c *manifestCache) get(path string, size, mtime int64) ([]ChunkInfo, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	m, ok := c.entries[path]
+	if !ok || m.size != size || m.mtime != mtime {
+		return nil, false
+	}
+	return m.manifest, true
+}
+
+func (c *manifestCache) put(path string, size, mtime int64, manifest []ChunkInfo) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[path] = &cachedManifest{size: size, mtime: mtime, manifest: manifest}
+}
@@ -0,0 +1,29 @@
+package socfs
+
+import "testing"
+
+func TestManifestCache_GetPut(t *testing.T) {
+	c := newManifestCache()
+	want := []ChunkInfo{{Offset: 0, Len: 4, Hash: "abcd"}}
+
+	if _, ok := c.get("/a.bin", 4, 100); ok {
+		t.Fatal("expected miss before put")
+	}
+	c.put("/a.bin", 4, 100, want)
+	got, ok := c.get("/a.bin", 4, 100)
+	if !ok || len(got) != 1 || got[0] != want[0] {
+		t.Fatalf("got %v, %v", got, ok)
+	}
+}
+
+func TestManifestCache_InvalidatesOnRevisionChange(t *testing.T) {
+	c := newManifestCache()
+	c.put("/a.bin", 4, 100, []ChunkInfo{{Offset: 0, Len: 4, Hash: "abcd"}})
+
+	if _, ok := c.get("/a.bin", 4, 200); ok {
+		t.Fatal("expected miss after mtime change")
+	}
+	if _, ok := c.get("/a.bin", 8, 100); ok {
+		t.Fatal("expected miss after size change")
+	}
+}
@@ -0,0 +1,86 @@
+package socfs
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// TokenBucket is a simple bytes/sec token-bucket rate limiter. A nil
+// *TokenBucket is treated as unlimited, so it's always safe to call
+// WaitN on whatever a caller's (possibly unset) limiter field holds.
+type TokenBucket struct {
+	rate  float64 // bytes per second
+	burst float64
+
+	mu       sync.Mutex
+	tokens   float64
+	lastFill time.Time
+}
+
+// NewTokenBucket creates a limiter capped at bytesPerSec bytes/sec with
+// a burst equal to one second's worth of bytes. bytesPerSec <= 0
+// returns nil, i.e. unlimited.
+func NewTokenBucket(bytesPerSec int64) *TokenBucket {
+	if bytesPerSec <= 0 {
+		return nil
+	}
+	return &TokenBucket{
+		rate: float64(bytesPerSec), burst: float64(bytesPerSec),
+		tokens: float64(bytesPerSec), lastFill: time.Now(),
+	}
+}
+
+// WaitN blocks until n bytes worth of tokens are available, or ctx is
+// done, whichever comes first.
+func (b *TokenBucket) WaitN(ctx context.Context, n int) error {
+	if b == nil || n <= 0 {
+		return nil
+	}
+	for {
+		wait := b.reserve(n)
+		if wait <= 0 {
+			return nil
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// reserve refills tokens for elapsed time and, if enough are available,
+// consumes n and returns 0. Otherwise it returns how long the caller
+// should wait before trying again.
+//
+// A request for more than a full bucket (n > burst) would otherwise
+// never be satisfied, since tokens are capped at burst on every
+// refill; such a request is admitted for the price of a full bucket
+// instead of hanging forever. Per-call throttling is therefore accurate
+// only while n is at most burst; FSClient passes at most MaxReadSize per
+// call, which stays within that when bytesPerSec >= MaxReadSize.
+func (b *TokenBucket) reserve(n int) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.tokens += now.Sub(b.lastFill).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.lastFill = now
+
+	need := float64(n)
+	if need > b.burst {
+		need = b.burst
+	}
+
+	if b.tokens >= need {
+		b.tokens -= need
+		return 0
+	}
+	return time.Duration((need - b.tokens) / b.rate * float64(time.Second))
+}
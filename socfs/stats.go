@@ -0,0 +1,111 @@
+package socfs
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// PathStats aggregates bytes transferred for a single path.
+type PathStats struct {
+	BytesIn  uint64
+	BytesOut uint64
+}
+
+// Stats tracks cross-cutting bandwidth and operation counters: total
+// bytes in/out, per-op counts, per-path transfer totals, and the
+// number of requests currently in flight. It's safe for concurrent
+// use and is embedded in FSClient (and, server-side, FSServer).
+type Stats struct {
+	bytesIn  uint64
+	bytesOut uint64
+	inFlight int64
+
+	mu    sync.Mutex
+	ops   map[string]uint64
+	paths map[string]*PathStats
+}
+
+// NewStats returns an empty Stats.
+func NewStats() *Stats {
+	return &Stats{ops: map[string]uint64{}, paths: map[string]*PathStats{}}
+}
+
+// BeginOp records the start of an operation and returns a func to call
+// when it finishes.
+func (s *Stats) BeginOp(op string) func() {
+	if s == nil {
+		return func() {}
+	}
+	atomic.AddInt64(&s.inFlight, 1)
+	s.mu.Lock()
+	s.ops[op]++
+	s.mu.Unlock()
+	return func() { atomic.AddInt64(&s.inFlight, -1) }
+}
+
+// AddRead records n bytes read from path.
+func (s *Stats) AddRead(path string, n int) {
+	if s == nil || n <= 0 {
+		return
+	}
+	atomic.AddUint64(&s.bytesOut, uint64(n))
+	s.mu.Lock()
+	s.pathLocked(path).BytesOut += uint64(n)
+	s.mu.Unlock()
+}
+
+// AddWrite records n bytes written to path.
+func (s *Stats) AddWrite(path string, n int) {
+	if s == nil || n <= 0 {
+		return
+	}
+	atomic.AddUint64(&s.bytesIn, uint64(n))
+	s.mu.Lock()
+	s.pathLocked(path).BytesIn += uint64(n)
+	s.mu.Unlock()
+}
+
+// pathLocked returns (creating if needed) the PathStats for path. The
+// caller must hold s.mu.
+func (s *Stats) pathLocked(path string) *PathStats {
+	p, ok := s.paths[path]
+	if !ok {
+		p = &PathStats{}
+		s.paths[path] = p
+	}
+	return p
+}
+
+// StatsSnapshot is a point-in-time, JSON-serializable copy of Stats.
+type StatsSnapshot struct {
+	BytesIn  uint64
+	BytesOut uint64
+	InFlight int64
+	Ops      map[string]uint64
+	Paths    map[string]PathStats
+}
+
+// Snapshot copies the current counters. It's the only way to read
+// Stats; there are no individual getters.
+func (s *Stats) Snapshot() *StatsSnapshot {
+	if s == nil {
+		return &StatsSnapshot{Ops: map[string]uint64{}, Paths: map[string]PathStats{}}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snap := &StatsSnapshot{
+		BytesIn:  atomic.LoadUint64(&s.bytesIn),
+		BytesOut: atomic.LoadUint64(&s.bytesOut),
+		InFlight: atomic.LoadInt64(&s.inFlight),
+		Ops:      make(map[string]uint64, len(s.ops)),
+		Paths:    make(map[string]PathStats, len(s.paths)),
+	}
+	for k, v := range s.ops {
+		snap.Ops[k] = v
+	}
+	for k, v := range s.paths {
+		snap.Paths[k] = *v
+	}
+	return snap
+}
@@ -0,0 +1,55 @@
+package socfs
+
+import "testing"
+
+func TestStats_AddReadWrite(t *testing.T) {
+	s := NewStats()
+	s.AddRead("/a.bin", 10)
+	s.AddRead("/a.bin", 5)
+	s.AddWrite("/b.bin", 3)
+
+	snap := s.Snapshot()
+	if snap.BytesOut != 15 {
+		t.Errorf("BytesOut = %d, want 15", snap.BytesOut)
+	}
+	if snap.BytesIn != 3 {
+		t.Errorf("BytesIn = %d, want 3", snap.BytesIn)
+	}
+	if snap.Paths["/a.bin"].BytesOut != 15 {
+		t.Errorf("paths[/a.bin].BytesOut = %d, want 15", snap.Paths["/a.bin"].BytesOut)
+	}
+	if snap.Paths["/b.bin"].BytesIn != 3 {
+		t.Errorf("paths[/b.bin].BytesIn = %d, want 3", snap.Paths["/b.bin"].BytesIn)
+	}
+}
+
+func TestStats_BeginOpTracksInFlightAndCounts(t *testing.T) {
+	s := NewStats()
+	done1 := s.BeginOp("read")
+	done2 := s.BeginOp("read")
+
+	snap := s.Snapshot()
+	if snap.InFlight != 2 {
+		t.Fatalf("InFlight = %d, want 2", snap.InFlight)
+	}
+	if snap.Ops["read"] != 2 {
+		t.Fatalf("Ops[read] = %d, want 2", snap.Ops["read"])
+	}
+
+	done1()
+	done2()
+	if s.Snapshot().InFlight != 0 {
+		t.Fatalf("InFlight after done = %d, want 0", s.Snapshot().InFlight)
+	}
+}
+
+func TestStats_NilIsNoOp(t *testing.T) {
+	var s *Stats
+	s.AddRead("/a.bin", 10)
+	s.AddWrite("/a.bin", 10)
+	done := s.BeginOp("read")
+	done()
+	if snap := s.Snapshot(); snap.BytesIn != 0 || snap.BytesOut != 0 {
+		t.Fatalf("expected zero snapshot from nil Stats, got %+v", snap)
+	}
+}